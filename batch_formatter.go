@@ -0,0 +1,84 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+func init() {
+	RegisterFormatter("aws.batch", batchFormatter{})
+}
+
+// BatchEventDetail extracts the fields needed to render an AWS Batch job
+// state change event.
+type BatchEventDetail struct {
+	JobName      string `json:"jobName"`
+	JobQueue     string `json:"jobQueue"`
+	Status       string `json:"status"`
+	StatusReason string `json:"statusReason"`
+}
+
+// batchFormatter formats "aws.batch" CloudWatch events.
+type batchFormatter struct{}
+
+func (batchFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	detail := BatchEventDetail{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return nil, err
+	}
+
+	color := "good"
+	if detail.Status == "FAILED" {
+		color = "danger"
+	}
+
+	footer, footerIcon, ts := standardFooter(event)
+	attachment := slack.Attachment{
+		Color:      color,
+		Title:      event.DetailType,
+		Text:       detail.StatusReason,
+		Footer:     footer,
+		FooterIcon: footerIcon,
+		Ts:         ts,
+		AttachmentField: []slack.AttachmentField{
+			{
+				Title: "Job",
+				Value: detail.JobName,
+				Short: true,
+			},
+			{
+				Title: "Queue",
+				Value: detail.JobQueue,
+				Short: true,
+			},
+			{
+				Title: "Status",
+				Value: detail.Status,
+				Short: true,
+			},
+			{
+				Title: "AccountID",
+				Value: event.AccountID,
+				Short: true,
+			},
+		},
+	}
+
+	return []slack.Attachment{attachment}, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+func init() {
+	RegisterFormatter("aws.codepipeline", codePipelineFormatter{})
+}
+
+// CodePipelineEventDetail covers the fields present on both pipeline- and
+// stage/action-execution state change events.
+type CodePipelineEventDetail struct {
+	Pipeline string `json:"pipeline"`
+	Stage    string `json:"stage"`
+	Action   string `json:"action"`
+	State    string `json:"state"`
+}
+
+// codePipelineFormatter formats "aws.codepipeline" CloudWatch events.
+type codePipelineFormatter struct{}
+
+func (codePipelineFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	detail := CodePipelineEventDetail{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return nil, err
+	}
+
+	color := "good"
+	switch detail.State {
+	case "FAILED", "CANCELED":
+		color = "danger"
+	case "STARTED", "RESUMED":
+		color = "warning"
+	}
+
+	footer, footerIcon, ts := standardFooter(event)
+	fields := []slack.AttachmentField{
+		{
+			Title: "Pipeline",
+			Value: detail.Pipeline,
+			Short: true,
+		},
+		{
+			Title: "State",
+			Value: detail.State,
+			Short: true,
+		},
+	}
+	if detail.Stage != "" {
+		fields = append(fields, slack.AttachmentField{
+			Title: "Stage",
+			Value: detail.Stage,
+			Short: true,
+		})
+	}
+	if detail.Action != "" {
+		fields = append(fields, slack.AttachmentField{
+			Title: "Action",
+			Value: detail.Action,
+			Short: true,
+		})
+	}
+	fields = append(fields,
+		slack.AttachmentField{
+			Title: "AccountID",
+			Value: event.AccountID,
+			Short: true,
+		},
+		slack.AttachmentField{
+			Title: "Region",
+			Value: event.Region,
+			Short: true,
+		},
+	)
+
+	attachment := slack.Attachment{
+		Color:           color,
+		Title:           event.DetailType,
+		Footer:          footer,
+		FooterIcon:      footerIcon,
+		Ts:              ts,
+		AttachmentField: fields,
+	}
+
+	return []slack.Attachment{attachment}, nil
+}
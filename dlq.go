@@ -0,0 +1,72 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// dlqURL is the SQS queue events are pushed to once Slack delivery has
+// exhausted its retries. Left empty, HandleRequest instead returns the
+// error so Lambda's own async retry / on-failure destination fires.
+var dlqURL string
+var sqsClient *sqs.SQS
+
+// dlqInitLog is used instead of main.go's Error logger, since Go runs
+// init funcs in file name order and dlq.go's can run before main.go's
+// initializes Error/Warning/Info, which would otherwise be a nil-logger
+// panic at cold start.
+var dlqInitLog = log.New(os.Stderr, "[ERROR]: ", log.Ldate|log.Ltime|log.Lshortfile)
+
+func init() {
+	dlqURL = os.Getenv("DLQ_URL")
+	if dlqURL == "" {
+		return
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		dlqInitLog.Println(err)
+		return
+	}
+	sqsClient = sqs.New(sess)
+}
+
+// sendToDLQ pushes the original CloudWatch event to the DLQ so it isn't
+// lost after Slack delivery permanently fails.
+func sendToDLQ(event events.CloudWatchEvent) error {
+	if sqsClient == nil {
+		return fmt.Errorf("DLQ_URL is set but the SQS client failed to initialize")
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = sqsClient.SendMessage(&sqs.SendMessageInput{
+		QueueUrl:    aws.String(dlqURL),
+		MessageBody: aws.String(string(body)),
+	})
+	return err
+}
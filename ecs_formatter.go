@@ -0,0 +1,85 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+func init() {
+	RegisterFormatter("aws.ecs", ecsFormatter{})
+}
+
+// ECSEventDetail extracts the fields needed to render an ECS Task/Service
+// state change event.
+type ECSEventDetail struct {
+	ClusterArn    string `json:"clusterArn"`
+	LastStatus    string `json:"lastStatus"`
+	DesiredStatus string `json:"desiredStatus"`
+	StoppedReason string `json:"stoppedReason"`
+	Group         string `json:"group"`
+}
+
+// ecsFormatter formats "aws.ecs" CloudWatch events.
+type ecsFormatter struct{}
+
+func (ecsFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	detail := ECSEventDetail{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return nil, err
+	}
+
+	color := "good"
+	if detail.LastStatus == "STOPPED" && detail.LastStatus != detail.DesiredStatus {
+		color = "danger"
+	}
+
+	footer, footerIcon, ts := standardFooter(event)
+	attachment := slack.Attachment{
+		Color:      color,
+		Title:      event.DetailType,
+		Text:       detail.StoppedReason,
+		Footer:     footer,
+		FooterIcon: footerIcon,
+		Ts:         ts,
+		AttachmentField: []slack.AttachmentField{
+			{
+				Title: "Cluster",
+				Value: detail.ClusterArn,
+				Short: true,
+			},
+			{
+				Title: "Group",
+				Value: detail.Group,
+				Short: true,
+			},
+			{
+				Title: "Last Status",
+				Value: detail.LastStatus,
+				Short: true,
+			},
+			{
+				Title: "Desired Status",
+				Value: detail.DesiredStatus,
+				Short: true,
+			},
+		},
+	}
+
+	return []slack.Attachment{attachment}, nil
+}
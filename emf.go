@@ -0,0 +1,56 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// emfNamespace is the CloudWatch metrics namespace SlackSendSuccess/
+// SlackSendRetry/SlackSendFailure are published under.
+const emfNamespace = "CloudWatchEventNotifier"
+
+// putMetric writes one line of Embedded Metric Format JSON to stdout
+// recording a single occurrence of name (e.g. "SlackSendRetry"). CloudWatch
+// Logs turns EMF lines into real metrics with no extra API calls or SDK
+// dependency, which is why this doesn't go through the aws-sdk-go
+// CloudWatch client like the rest of this lambda's AWS calls.
+func putMetric(name string, value float64) {
+	payload := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": time.Now().UnixNano() / int64(time.Millisecond),
+			"CloudWatchMetrics": []map[string]interface{}{
+				{
+					"Namespace":  emfNamespace,
+					"Dimensions": [][]string{{}},
+					"Metrics": []map[string]string{
+						{"Name": name, "Unit": "Count"},
+					},
+				},
+			},
+		},
+		name: value,
+	}
+
+	line, err := json.Marshal(payload)
+	if err != nil {
+		Error.Println(err)
+		return
+	}
+
+	fmt.Println(string(line))
+}
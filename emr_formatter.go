@@ -0,0 +1,82 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+func init() {
+	RegisterFormatter("aws.emr", emrFormatter{})
+}
+
+// EMREventDetail struct to extract out a few key attributes
+type EMREventDetail struct {
+	Severity string `json:"severity"`
+	State    string `json:"state"`
+	Message  string `json:"message"`
+}
+
+// emrFormatter formats "aws.emr" CloudWatch events.
+type emrFormatter struct{}
+
+func (emrFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	emrEventDetail := EMREventDetail{}
+	if err := json.Unmarshal(event.Detail, &emrEventDetail); err != nil {
+		return nil, err
+	}
+
+	color := "good"
+	if emrEventDetail.Severity == "ERROR" {
+		color = "danger"
+	}
+
+	footer, footerIcon, ts := standardFooter(event)
+	attachment := slack.Attachment{
+		Color:      color,
+		Title:      event.DetailType,
+		Text:       emrEventDetail.Message,
+		Footer:     footer,
+		FooterIcon: footerIcon,
+		Ts:         ts,
+		AttachmentField: []slack.AttachmentField{
+			{
+				Title: "AccountID",
+				Value: event.AccountID,
+				Short: true,
+			},
+			{
+				Title: "Region",
+				Value: event.Region,
+				Short: true,
+			},
+			{
+				Title: "State",
+				Value: emrEventDetail.State,
+				Short: true,
+			},
+			{
+				Title: "Time",
+				Value: event.Time.String(),
+				Short: true,
+			},
+		},
+	}
+
+	return []slack.Attachment{attachment}, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+// EventFormatter turns a CloudWatch event into one or more Slack attachments.
+// Implementations are registered against the event's Source (e.g. "aws.emr")
+// via RegisterFormatter and are looked up by HandleRequest.
+type EventFormatter interface {
+	Format(event events.CloudWatchEvent) ([]slack.Attachment, error)
+}
+
+// formatterRegistry holds the EventFormatter for each known event Source.
+var formatterRegistry = map[string]EventFormatter{}
+
+// RegisterFormatter associates an EventFormatter with a CloudWatch event
+// source (e.g. "aws.codepipeline"). Downstream users can call this from
+// their own init() to plug in support for sources this package doesn't
+// know about, without forking the lambda.
+func RegisterFormatter(source string, f EventFormatter) {
+	formatterRegistry[source] = f
+}
+
+// formatterFor returns the EventFormatter registered for event.Source,
+// falling back to defaultFormatter when the source is unknown.
+func formatterFor(source string) EventFormatter {
+	if f, ok := formatterRegistry[source]; ok {
+		return f
+	}
+	return defaultFormatter{}
+}
+
+// standardFooter builds the footer fields every formatter attaches so
+// messages look consistent regardless of the event source.
+func standardFooter(event events.CloudWatchEvent) (string, string, int64) {
+	return functionName(), footerIcon, time.Now().UnixNano() / int64(time.Second)
+}
+
+// defaultFormatter pretty-prints the raw Detail JSON for event sources that
+// have no dedicated EventFormatter registered, so unknown events are still
+// surfaced instead of silently dropped.
+type defaultFormatter struct{}
+
+func (defaultFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	var pretty interface{}
+	if err := json.Unmarshal(event.Detail, &pretty); err != nil {
+		return nil, err
+	}
+
+	detailJSON, err := json.MarshalIndent(pretty, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	footer, footerIcon, ts := standardFooter(event)
+	attachment := slack.Attachment{
+		Color:      "warning",
+		Title:      fmt.Sprintf("%s (%s)", event.DetailType, event.Source),
+		Text:       fmt.Sprintf("```%s```", string(detailJSON)),
+		Footer:     footer,
+		FooterIcon: footerIcon,
+		Ts:         ts,
+		AttachmentField: []slack.AttachmentField{
+			{
+				Title: "AccountID",
+				Value: event.AccountID,
+				Short: true,
+			},
+			{
+				Title: "Region",
+				Value: event.Region,
+				Short: true,
+			},
+			{
+				Title: "Time",
+				Value: event.Time.String(),
+				Short: true,
+			},
+		},
+	}
+
+	return []slack.Attachment{attachment}, nil
+}
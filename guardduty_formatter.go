@@ -0,0 +1,96 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+func init() {
+	RegisterFormatter("aws.guardduty", guardDutyFormatter{})
+}
+
+// GuardDutyEventDetail extracts the fields needed to render a GuardDuty
+// finding. Severity is a float ranging 0.1-8.9 per the GuardDuty finding
+// format and is bucketed into Low/Medium/High for display.
+type GuardDutyEventDetail struct {
+	Type        string  `json:"type"`
+	Severity    float64 `json:"severity"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+}
+
+// guardDutyFormatter formats "aws.guardduty" CloudWatch events.
+type guardDutyFormatter struct{}
+
+func (guardDutyFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	detail := GuardDutyEventDetail{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return nil, err
+	}
+
+	severityLabel, color := guardDutySeverity(detail.Severity)
+
+	footer, footerIcon, ts := standardFooter(event)
+	attachment := slack.Attachment{
+		Color:      color,
+		Title:      detail.Title,
+		Text:       detail.Description,
+		Footer:     footer,
+		FooterIcon: footerIcon,
+		Ts:         ts,
+		AttachmentField: []slack.AttachmentField{
+			{
+				Title: "Finding Type",
+				Value: detail.Type,
+				Short: true,
+			},
+			{
+				Title: "Severity",
+				Value: fmt.Sprintf("%s (%.1f)", severityLabel, detail.Severity),
+				Short: true,
+			},
+			{
+				Title: "AccountID",
+				Value: event.AccountID,
+				Short: true,
+			},
+			{
+				Title: "Region",
+				Value: event.Region,
+				Short: true,
+			},
+		},
+	}
+
+	return []slack.Attachment{attachment}, nil
+}
+
+// guardDutySeverity buckets a GuardDuty severity score into the Low/Medium/
+// High ranges documented by AWS and maps them to a Slack attachment color.
+func guardDutySeverity(severity float64) (string, string) {
+	switch {
+	case severity >= 7.0:
+		return "High", "danger"
+	case severity >= 4.0:
+		return "Medium", "warning"
+	default:
+		return "Low", "good"
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+func init() {
+	RegisterFormatter("aws.health", healthFormatter{})
+}
+
+// HealthEventDetail extracts the fields needed to render an AWS Health
+// (Personal Health Dashboard) event.
+type HealthEventDetail struct {
+	Service           string `json:"service"`
+	EventTypeCode     string `json:"eventTypeCode"`
+	EventTypeCategory string `json:"eventTypeCategory"`
+	StatusCode        string `json:"statusCode"`
+	EventDescription  []struct {
+		LatestDescription string `json:"latestDescription"`
+	} `json:"eventDescription"`
+}
+
+// healthFormatter formats "aws.health" CloudWatch events.
+type healthFormatter struct{}
+
+func (healthFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	detail := HealthEventDetail{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return nil, err
+	}
+
+	color := "good"
+	switch detail.EventTypeCategory {
+	case "issue":
+		color = "danger"
+	case "scheduledChange":
+		color = "warning"
+	}
+
+	text := ""
+	if len(detail.EventDescription) > 0 {
+		text = detail.EventDescription[0].LatestDescription
+	}
+
+	footer, footerIcon, ts := standardFooter(event)
+	attachment := slack.Attachment{
+		Color:      color,
+		Title:      detail.EventTypeCode,
+		Text:       text,
+		Footer:     footer,
+		FooterIcon: footerIcon,
+		Ts:         ts,
+		AttachmentField: []slack.AttachmentField{
+			{
+				Title: "Service",
+				Value: detail.Service,
+				Short: true,
+			},
+			{
+				Title: "Status",
+				Value: detail.StatusCode,
+				Short: true,
+			},
+			{
+				Title: "AccountID",
+				Value: event.AccountID,
+				Short: true,
+			},
+			{
+				Title: "Region",
+				Value: event.Region,
+				Short: true,
+			},
+		},
+	}
+
+	return []slack.Attachment{attachment}, nil
+}
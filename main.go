@@ -17,9 +17,11 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -27,6 +29,15 @@ import (
 	"github.com/jmoney8080/go-gadget-slack"
 )
 
+// footerIcon is the icon shown on every Slack attachment this lambda posts.
+const footerIcon = "https://d1d05r7k0qlw4w.cloudfront.net/dist-cbe91c5a8477701757ff6752aae4c6f892018972/img/favicon.ico"
+
+// maxInlineEventBytes is the size past which sendToChannel uploads the raw
+// event as a snippet (threaded under the message) instead of relying on the
+// attachment text to carry it, since Slack attachments are meant for a
+// summary, not a full payload dump.
+const maxInlineEventBytes = 4000
+
 var (
 	// Info Logger
 	Info *log.Logger
@@ -40,11 +51,10 @@ var (
 	slackMonitorChannel       string
 )
 
-// EMREventDetail struct to extract out a few key attributes
-type EMREventDetail struct {
-	Severity string `json:"severity"`
-	State    string `json:"state"`
-	Message  string `json:"message"`
+// functionName returns the name of the currently executing Lambda function,
+// used as the Slack attachment footer.
+func functionName() string {
+	return os.Getenv("AWS_LAMBDA_FUNCTION_NAME")
 }
 
 func init() {
@@ -72,77 +82,125 @@ func main() {
 
 // HandleRequest function that the lambda runtime service calls
 func HandleRequest(ctx context.Context, event events.CloudWatchEvent) error {
-	slackAttachments := []slack.Attachment{}
+	var username, icon string
+	if tmpl, found := templateFor(event.Source); found {
+		username = tmpl.Username
+		icon = tmpl.Icon
+	}
 
-	if event.Source == "aws.emr" {
-		emrEventDetail := EMREventDetail{}
-		err := json.Unmarshal(event.Detail, &emrEventDetail)
+	slackAttachments, _, overridden := applyTemplate(event)
+	if !overridden {
+		formatter := formatterFor(event.Source)
+		attachments, err := formatter.Format(event)
 		if err != nil {
 			Error.Println(err)
 			return nil
 		}
+		slackAttachments = attachments
+	}
 
-		color := "good"
-		if emrEventDetail.Severity == "ERROR" {
-			color = "danger"
-		}
+	if len(slackAttachments) == 0 {
+		Warning.Println("No Slack Sent")
+		return nil
+	}
 
-		slackAttachment := slack.Attachment{
-			Color:      color,
-			Title:      event.DetailType,
-			Text:       emrEventDetail.Message,
-			Footer:     os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
-			FooterIcon: "https://d1d05r7k0qlw4w.cloudfront.net/dist-cbe91c5a8477701757ff6752aae4c6f892018972/img/favicon.ico",
-			Ts:         time.Now().UnixNano() / int64(time.Second),
-			AttachmentField: []slack.AttachmentField{
-				{
-					Title: "AccountID",
-					Value: event.AccountID,
-					Short: true,
-				},
-				{
-					Title: "Region",
-					Value: event.Region,
-					Short: true,
-				},
-				{
-					Title: "State",
-					Value: emrEventDetail.State,
-					Short: true,
-				},
-				{
-					Title: "Time",
-					Value: event.Time.String(),
-					Short: true,
-				},
-			},
+	channels := router.Route(event, severityOf(event))
+	if len(channels) == 0 {
+		Warning.Println("No channel matched routing rules, No Slack Sent")
+		return nil
+	}
+
+	var lastErr error
+	for _, channel := range channels {
+		if err := sendToChannel(event, channel, username, icon, slackAttachments); err != nil {
+			lastErr = err
 		}
-		slackAttachments = append(slackAttachments, slackAttachment)
 	}
 
-	if len(slackAttachments) != 0 {
-		// Here we are chunking up the attachments.  Slack only allows 100 attachments in one post. While that'd be insane and absurd to do, it's a known limit
-		// we can easily account for in the code
-		for i := 0; i < len(slackAttachments); i += slackAttachmentsChunkSize {
-			end := i + slackAttachmentsChunkSize
-			if end > len(slackAttachments) {
-				end = len(slackAttachments)
-			}
+	if lastErr == nil {
+		return nil
+	}
 
-			chunkedSlackAttachments := slackAttachments[i:end]
-			payload := slack.Payload{
-				Channel:     slackMonitorChannel,
-				Attachments: chunkedSlackAttachments,
-			}
-			resp, err := (*slackClient).Send(payload)
-			if err != nil {
+	if dlqURL == "" {
+		return lastErr
+	}
+
+	if err := sendToDLQ(event); err != nil {
+		Error.Println(err)
+		return err
+	}
+	return nil
+}
+
+// sendToChannel posts slackAttachments to channel, chunked to Slack's
+// 100-attachments-per-message limit and threaded under whatever thread_ts
+// this event's resource has previously been posted under. Each chunk is
+// sent through sendWithRetry so a transient failure doesn't drop the event.
+func sendToChannel(event events.CloudWatchEvent, channel, username, icon string, slackAttachments []slack.Attachment) error {
+	key := channel + "|" + threadKey(event)
+	threadTS, _ := threadStore.Get(key)
+
+	// Here we are chunking up the attachments.  Slack only allows 100 attachments in one post. While that'd be insane and absurd to do, it's a known limit
+	// we can easily account for in the code
+	var lastErr error
+	for i := 0; i < len(slackAttachments); i += slackAttachmentsChunkSize {
+		end := i + slackAttachmentsChunkSize
+		if end > len(slackAttachments) {
+			end = len(slackAttachments)
+		}
+
+		message := Message{
+			Channel:     channel,
+			Username:    username,
+			Attachments: slackAttachments[i:end],
+			ThreadTS:    threadTS,
+		}
+		if strings.HasPrefix(icon, ":") {
+			message.IconEmoji = icon
+		} else if icon != "" {
+			message.IconURL = icon
+		}
+
+		ts, err := sendWithRetry(slackTransportImpl, message)
+		if err != nil {
+			Error.Println(err)
+			lastErr = err
+			continue
+		}
+
+		if threadTS == "" && ts != "" {
+			threadTS = ts
+			if err := threadStore.Put(key, ts); err != nil {
 				Error.Println(err)
-			} else {
-				Info.Println(resp)
 			}
 		}
-	} else {
-		Warning.Println("No Slack Sent")
 	}
-	return nil
+
+	if lastErr == nil || threadTS != "" {
+		uploadEventSnippet(event, channel, threadTS)
+	}
+	return lastErr
+}
+
+// uploadEventSnippet attaches the raw event payload as a text snippet,
+// threaded under threadTS, when the transport supports it (see
+// SnippetUploader) and the payload is too large to reasonably inline into
+// an attachment. Failures are logged, not propagated: the message itself
+// already made it to Slack, so a snippet upload failure shouldn't fail the
+// whole send.
+func uploadEventSnippet(event events.CloudWatchEvent, channel, threadTS string) {
+	uploader, ok := slackTransportImpl.(SnippetUploader)
+	if !ok {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil || len(body) <= maxInlineEventBytes {
+		return
+	}
+
+	filename := fmt.Sprintf("%s-event.json", strings.ReplaceAll(event.Source, ".", "-"))
+	if err := uploader.UploadSnippet(channel, filename, threadTS, body); err != nil {
+		Error.Println(err)
+	}
 }
@@ -0,0 +1,81 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// maxSendAttempts is the total number of times sendWithRetry will call
+// SlackTransport.Send for a single message before giving up.
+const maxSendAttempts = 5
+
+// baseRetryDelay is the starting point for the exponential backoff between
+// attempts; it doubles on every retry, and the actual sleep is a random
+// duration below it (full jitter).
+const baseRetryDelay = 500 * time.Millisecond
+
+// RetryAfterError is returned by a SlackTransport when Slack responds with
+// a rate limit (HTTP 429) and tells the caller how long to wait before
+// trying again.
+type RetryAfterError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// sendWithRetry calls transport.Send, retrying on error with exponential
+// backoff and jitter, for at most maxSendAttempts attempts in all. A RetryAfterError waits
+// exactly as long as Slack asked for instead of the computed backoff.
+// Every attempt emits an EMF metric so CloudWatch alarms can be built on
+// SlackSendRetry/SlackSendFailure counts.
+func sendWithRetry(transport SlackTransport, message Message) (string, error) {
+	var lastErr error
+
+	for attempt := 0; attempt < maxSendAttempts; attempt++ {
+		ts, err := transport.Send(message)
+		if err == nil {
+			putMetric("SlackSendSuccess", 1)
+			return ts, nil
+		}
+
+		lastErr = err
+		if attempt == maxSendAttempts-1 {
+			break
+		}
+
+		putMetric("SlackSendRetry", 1)
+		time.Sleep(retryDelay(attempt, err))
+	}
+
+	putMetric("SlackSendFailure", 1)
+	return "", lastErr
+}
+
+// retryDelay computes how long to sleep before the next attempt: the
+// Retry-After Slack sent on a 429, or an exponential backoff with full
+// jitter otherwise.
+func retryDelay(attempt int, err error) time.Duration {
+	if rateLimitErr, ok := err.(*RetryAfterError); ok {
+		return rateLimitErr.RetryAfter
+	}
+
+	backoff := baseRetryDelay << uint(attempt)
+	return time.Duration(rand.Int63n(int64(backoff)))
+}
@@ -0,0 +1,215 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// Router decides which Slack channels an event should be posted to,
+// replacing the single SLACK_MONITOR_CHANNEL env var with something that
+// can route on source, detail type, severity, account, and region. The
+// mapping source is pluggable: see RoutingConfig (loaded from the
+// SLACK_ROUTING_CONFIG env var), ssmRouter and dynamoRouter.
+type Router interface {
+	Route(event events.CloudWatchEvent, severity string) []string
+}
+
+// RouteRule maps one (source, detail-type, severity, accountID, region)
+// tuple to the channels matching events should be sent to. Any field left
+// empty, or set to "*", matches anything.
+type RouteRule struct {
+	Source     string   `json:"source"`
+	DetailType string   `json:"detailType"`
+	Severity   string   `json:"severity"`
+	AccountID  string   `json:"accountId"`
+	Region     string   `json:"region"`
+	Channels   []string `json:"channels"`
+}
+
+// RoutingConfig is an ordered list of RouteRules, the first of which to
+// match wins, plus a DefaultChannel used when nothing matches.
+type RoutingConfig struct {
+	Rules          []RouteRule `json:"rules"`
+	DefaultChannel string      `json:"defaultChannel"`
+}
+
+// Route implements Router by returning the Channels of the first matching
+// rule, or DefaultChannel when none match.
+func (c RoutingConfig) Route(event events.CloudWatchEvent, severity string) []string {
+	for _, rule := range c.Rules {
+		if routeRuleMatches(rule, event, severity) {
+			return rule.Channels
+		}
+	}
+
+	if c.DefaultChannel == "" {
+		return nil
+	}
+	return []string{c.DefaultChannel}
+}
+
+func routeRuleMatches(rule RouteRule, event events.CloudWatchEvent, severity string) bool {
+	return wildcardMatch(rule.Source, event.Source) &&
+		wildcardMatch(rule.DetailType, event.DetailType) &&
+		wildcardMatch(rule.Severity, severity) &&
+		wildcardMatch(rule.AccountID, event.AccountID) &&
+		wildcardMatch(rule.Region, event.Region)
+}
+
+// wildcardMatch reports whether value satisfies pattern, where an empty
+// pattern or "*" matches any value.
+func wildcardMatch(pattern, value string) bool {
+	return pattern == "" || pattern == "*" || pattern == value
+}
+
+// router is selected once at init time based on which routing env var is
+// set. Falling back to the legacy SLACK_MONITOR_CHANNEL keeps existing
+// deployments working unchanged.
+var router Router
+
+func init() {
+	switch {
+	case os.Getenv("SLACK_ROUTING_CONFIG") != "":
+		router = mustLoadRoutingConfig([]byte(os.Getenv("SLACK_ROUTING_CONFIG")))
+	case os.Getenv("SLACK_ROUTING_SSM_PARAMETER") != "":
+		router = newSSMRouter(os.Getenv("SLACK_ROUTING_SSM_PARAMETER"))
+	case os.Getenv("SLACK_ROUTING_TABLE") != "":
+		router = newDynamoRouter(os.Getenv("SLACK_ROUTING_TABLE"))
+	default:
+		router = RoutingConfig{DefaultChannel: slackMonitorChannel}
+	}
+}
+
+func mustLoadRoutingConfig(raw []byte) RoutingConfig {
+	config := RoutingConfig{}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		Error.Println(err)
+	}
+	return config
+}
+
+// ssmRouter loads its RoutingConfig once, from an SSM Parameter Store
+// parameter, at init time.
+type ssmRouter struct {
+	RoutingConfig
+}
+
+func newSSMRouter(parameterName string) ssmRouter {
+	sess, err := session.NewSession()
+	if err != nil {
+		Error.Println(err)
+		return ssmRouter{}
+	}
+
+	out, err := ssm.New(sess).GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(parameterName),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		Error.Println(err)
+		return ssmRouter{}
+	}
+
+	return ssmRouter{RoutingConfig: mustLoadRoutingConfig([]byte(aws.StringValue(out.Parameter.Value)))}
+}
+
+// dynamoRouter looks up routing rules in DynamoDB on every invocation,
+// keyed by event source, so routing can be changed without redeploying or
+// waiting on an SSM cache to expire. Each item's "rules" attribute holds the
+// JSON-encoded []RouteRule for that source; "default" holds that source's
+// DefaultChannel.
+type dynamoRouter struct {
+	table  string
+	client *dynamodb.DynamoDB
+}
+
+func newDynamoRouter(table string) dynamoRouter {
+	sess, err := session.NewSession()
+	if err != nil {
+		Error.Println(err)
+		return dynamoRouter{table: table}
+	}
+	return dynamoRouter{table: table, client: dynamodb.New(sess)}
+}
+
+func (r dynamoRouter) Route(event events.CloudWatchEvent, severity string) []string {
+	if r.client == nil {
+		return nil
+	}
+
+	out, err := r.client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(r.table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"source": {S: aws.String(event.Source)},
+		},
+	})
+	if err != nil {
+		Error.Println(err)
+		return nil
+	}
+	if out.Item == nil {
+		return nil
+	}
+
+	config := RoutingConfig{}
+	if rules, ok := out.Item["rules"]; ok && rules.S != nil {
+		if err := json.Unmarshal([]byte(*rules.S), &config.Rules); err != nil {
+			Error.Println(err)
+		}
+	}
+	if def, ok := out.Item["default"]; ok && def.S != nil {
+		config.DefaultChannel = *def.S
+	}
+
+	return config.Route(event, severity)
+}
+
+// severityDetail extracts a "severity" field common to many CloudWatch
+// event details, whether it's a string (EMR's "ERROR"/"INFO") or a number
+// (GuardDuty's 0.1-8.9 finding score).
+type severityDetail struct {
+	Severity json.RawMessage `json:"severity"`
+}
+
+// severityOf returns the severity Router rules should match against for
+// event, or "" when the detail has no recognizable severity field.
+func severityOf(event events.CloudWatchEvent) string {
+	detail := severityDetail{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil || len(detail.Severity) == 0 {
+		return ""
+	}
+
+	var asString string
+	if err := json.Unmarshal(detail.Severity, &asString); err == nil {
+		return asString
+	}
+
+	var asNumber float64
+	if err := json.Unmarshal(detail.Severity, &asNumber); err == nil {
+		label, _ := guardDutySeverity(asNumber)
+		return strings.ToUpper(label)
+	}
+
+	return ""
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+func init() {
+	RegisterFormatter("aws.securityhub", securityHubFormatter{})
+}
+
+// SecurityHubEventDetail extracts the fields needed to render a Security
+// Hub finding's "Findings - Imported" detail.
+type SecurityHubEventDetail struct {
+	Findings []struct {
+		Title    string `json:"Title"`
+		Severity struct {
+			Label string `json:"Label"`
+		} `json:"Severity"`
+		Description string `json:"Description"`
+		ProductArn  string `json:"ProductArn"`
+		Compliance  struct {
+			Status string `json:"Status"`
+		} `json:"Compliance"`
+	} `json:"findings"`
+}
+
+// securityHubFormatter formats "aws.securityhub" CloudWatch events,
+// producing one attachment per finding in the batch.
+type securityHubFormatter struct{}
+
+func (securityHubFormatter) Format(event events.CloudWatchEvent) ([]slack.Attachment, error) {
+	detail := SecurityHubEventDetail{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		return nil, err
+	}
+
+	footer, footerIcon, ts := standardFooter(event)
+	attachments := make([]slack.Attachment, 0, len(detail.Findings))
+	for _, finding := range detail.Findings {
+		color := "good"
+		switch finding.Severity.Label {
+		case "CRITICAL", "HIGH":
+			color = "danger"
+		case "MEDIUM":
+			color = "warning"
+		}
+
+		attachments = append(attachments, slack.Attachment{
+			Color:      color,
+			Title:      finding.Title,
+			Text:       finding.Description,
+			Footer:     footer,
+			FooterIcon: footerIcon,
+			Ts:         ts,
+			AttachmentField: []slack.AttachmentField{
+				{
+					Title: "Severity",
+					Value: finding.Severity.Label,
+					Short: true,
+				},
+				{
+					Title: "Compliance",
+					Value: finding.Compliance.Status,
+					Short: true,
+				},
+				{
+					Title: "Product",
+					Value: finding.ProductArn,
+					Short: true,
+				},
+				{
+					Title: "AccountID",
+					Value: event.AccountID,
+					Short: true,
+				},
+			},
+		})
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,251 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+const slackAPIBaseURL = "https://slack.com/api"
+
+// botTransport delivers messages through the Slack Web API using a bot
+// token, which is what unlocks threading (thread_ts) and file uploads that
+// an incoming webhook cannot do.
+type botTransport struct {
+	token      string
+	httpClient *http.Client
+}
+
+func newBotTransport(token string) botTransport {
+	return botTransport{
+		token:      token,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// postMessageRequest mirrors the subset of chat.postMessage's parameters
+// this lambda needs.
+type postMessageRequest struct {
+	Channel     string             `json:"channel"`
+	Username    string             `json:"username,omitempty"`
+	IconEmoji   string             `json:"icon_emoji,omitempty"`
+	IconURL     string             `json:"icon_url,omitempty"`
+	Attachments []slack.Attachment `json:"attachments,omitempty"`
+	ThreadTS    string             `json:"thread_ts,omitempty"`
+}
+
+// slackAPIResponse is the envelope every Slack Web API method responds
+// with, success or failure.
+type slackAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	Ts    string `json:"ts"`
+}
+
+func (t botTransport) Send(message Message) (string, error) {
+	req := postMessageRequest{
+		Channel:     message.Channel,
+		Username:    message.Username,
+		IconEmoji:   message.IconEmoji,
+		IconURL:     message.IconURL,
+		Attachments: message.Attachments,
+		ThreadTS:    message.ThreadTS,
+	}
+
+	resp := slackAPIResponse{}
+	if err := t.call("chat.postMessage", req, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.Ts, nil
+}
+
+// UploadSnippet attaches content (e.g. a CloudWatch Logs excerpt or the raw
+// event payload) to channel as a text snippet, threaded under threadTS when
+// set, using the files.getUploadURLExternal / files.completeUploadExternal
+// flow that replaced the deprecated files.upload.
+func (t botTransport) UploadSnippet(channel, filename, threadTS string, content []byte) error {
+	uploadURL, fileID, err := t.getUploadURLExternal(filename, len(content))
+	if err != nil {
+		return err
+	}
+
+	if err := t.putFile(uploadURL, content); err != nil {
+		return err
+	}
+
+	return t.completeUploadExternal(channel, threadTS, fileID, filename)
+}
+
+type getUploadURLExternalResponse struct {
+	slackAPIResponse
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+func (t botTransport) getUploadURLExternal(filename string, length int) (url string, fileID string, err error) {
+	form := map[string]string{
+		"filename": filename,
+		"length":   fmt.Sprintf("%d", length),
+	}
+
+	resp := getUploadURLExternalResponse{}
+	if err := t.callForm("files.getUploadURLExternal", form, &resp); err != nil {
+		return "", "", err
+	}
+
+	return resp.UploadURL, resp.FileID, nil
+}
+
+func (t botTransport) putFile(uploadURL string, content []byte) error {
+	req, err := http.NewRequest(http.MethodPost, uploadURL, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("files.getUploadURLExternal upload failed: %s: %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+func (t botTransport) completeUploadExternal(channel, threadTS, fileID, filename string) error {
+	files, err := json.Marshal([]map[string]string{
+		{"id": fileID, "title": filename},
+	})
+	if err != nil {
+		return err
+	}
+
+	form := map[string]string{
+		"channel_id": channel,
+		"files":      string(files),
+	}
+	if threadTS != "" {
+		form["thread_ts"] = threadTS
+	}
+
+	resp := slackAPIResponse{}
+	return t.callForm("files.completeUploadExternal", form, &resp)
+}
+
+// call POSTs body as JSON to the given Slack Web API method and decodes the
+// response, returning an error if Slack reports ok=false.
+func (t botTransport) call(method string, body interface{}, out *slackAPIResponse) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, slackAPIBaseURL+"/"+method, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+t.token)
+
+	return t.do(req, out)
+}
+
+// callForm posts body as multipart form fields, which is what
+// files.getUploadURLExternal and files.completeUploadExternal expect.
+func (t botTransport) callForm(method string, fields map[string]string, out interface{}) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, slackAPIBaseURL+"/"+method, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+
+	return t.doInto(req, out)
+}
+
+func (t botTransport) do(req *http.Request, out *slackAPIResponse) error {
+	return t.doInto(req, out)
+}
+
+func (t botTransport) doInto(req *http.Request, out interface{}) error {
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &RetryAfterError{
+			RetryAfter: retryAfterHeader(resp.Header.Get("Retry-After")),
+			Err:        fmt.Errorf("slack API rate limited: %s", resp.Status),
+		}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return err
+	}
+
+	if r, ok := out.(interface{ slackError() (bool, string) }); ok {
+		if ok, slackErr := r.slackError(); !ok {
+			return fmt.Errorf("slack API error: %s", slackErr)
+		}
+	}
+
+	return nil
+}
+
+func (r *slackAPIResponse) slackError() (bool, string) {
+	return r.OK, r.Error
+}
+
+// retryAfterHeader parses Slack's Retry-After header (seconds), falling
+// back to baseRetryDelay if it's missing or malformed.
+func retryAfterHeader(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return baseRetryDelay
+	}
+	return time.Duration(seconds) * time.Second
+}
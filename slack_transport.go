@@ -0,0 +1,88 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+// Message is the transport-agnostic representation of what HandleRequest
+// wants posted to Slack. ThreadTS, when set, asks the transport to reply in
+// an existing thread instead of starting a new message.
+type Message struct {
+	Channel     string
+	Username    string
+	IconEmoji   string
+	IconURL     string
+	Attachments []slack.Attachment
+	ThreadTS    string
+}
+
+// SlackTransport delivers a Message to Slack. Send returns the timestamp
+// ("ts") Slack assigned the message, which callers can persist and feed
+// back in as Message.ThreadTS to thread follow-up events.
+type SlackTransport interface {
+	Send(message Message) (ts string, err error)
+}
+
+// SnippetUploader is an optional capability a SlackTransport may implement
+// to attach content (a CloudWatch Logs excerpt, the raw event payload) as a
+// file rather than inlining it into an attachment. Only botTransport
+// implements it today; webhookTransport has no file-upload equivalent.
+type SnippetUploader interface {
+	UploadSnippet(channel, filename, threadTS string, content []byte) error
+}
+
+// slackTransportImpl is the transport HandleRequest sends through. It is
+// selected once at init time based on which credential env var is set:
+// SLACK_BOT_TOKEN opts into the chat.postMessage transport (threads, file
+// uploads); otherwise the original SLACK_WEBHOOK transport is used.
+var slackTransportImpl SlackTransport
+
+func init() {
+	if token := os.Getenv("SLACK_BOT_TOKEN"); token != "" {
+		slackTransportImpl = newBotTransport(token)
+		return
+	}
+
+	slackTransportImpl = webhookTransport{client: slackClient}
+}
+
+// webhookTransport sends messages through a Slack incoming webhook. Incoming
+// webhooks have no concept of threads, so ThreadTS is ignored and Send
+// always returns an empty ts.
+type webhookTransport struct {
+	client *slack.Client
+}
+
+func (t webhookTransport) Send(message Message) (string, error) {
+	payload := slack.Payload{
+		Channel:     message.Channel,
+		Username:    message.Username,
+		IconEmoji:   message.IconEmoji,
+		IconURL:     message.IconURL,
+		Attachments: message.Attachments,
+	}
+
+	resp, err := (*t.client).Send(payload)
+	if err != nil {
+		return "", err
+	}
+
+	Info.Println(resp)
+	return "", nil
+}
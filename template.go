@@ -0,0 +1,170 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/jmoney8080/go-gadget-slack"
+)
+
+// MessageTemplate lets operators override how a Slack message is rendered
+// for a given event source without recompiling the lambda. Username and
+// Icon are plain strings; Attachments is a Go text/template that must
+// render to a JSON array of slack.Attachment when executed against a
+// templateContext. Any field left blank falls back to the formatter's
+// default rendering.
+type MessageTemplate struct {
+	Username    string `json:"username"`
+	Icon        string `json:"icon"`
+	Attachments string `json:"attachments"`
+}
+
+// templateContext is the data a MessageTemplate's Attachments template is
+// evaluated against.
+type templateContext struct {
+	Event  events.CloudWatchEvent
+	Detail map[string]interface{}
+}
+
+// messageTemplates holds the MessageTemplate registered per event source,
+// keyed by event.Source, with "default" used when a source has no
+// dedicated override.
+var messageTemplates = map[string]MessageTemplate{}
+
+func init() {
+	location := os.Getenv("SLACK_TEMPLATE_CONFIG")
+	if location == "" {
+		return
+	}
+
+	raw, err := loadTemplateConfig(location)
+	if err != nil {
+		Error.Println(err)
+		return
+	}
+
+	if err := json.Unmarshal(raw, &messageTemplates); err != nil {
+		Error.Println(err)
+	}
+}
+
+// loadTemplateConfig reads the template config pointed to by location,
+// which is either an "s3://bucket/key" URI or inline JSON.
+func loadTemplateConfig(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		return []byte(location), nil
+	}
+
+	bucket, key, err := parseS3URI(location)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s3.New(sess).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return ioutil.ReadAll(out.Body)
+}
+
+// parseS3URI splits an "s3://bucket/key" URI into its bucket and key parts.
+func parseS3URI(uri string) (bucket string, key string, err error) {
+	trimmed := strings.TrimPrefix(uri, "s3://")
+	parts := strings.SplitN(trimmed, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid s3 uri: %s", uri)
+	}
+	return parts[0], parts[1], nil
+}
+
+// templateFor returns the MessageTemplate registered for source, falling
+// back to the "default" entry, and reports whether one was found.
+func templateFor(source string) (MessageTemplate, bool) {
+	if t, ok := messageTemplates[source]; ok {
+		return t, true
+	}
+	if t, ok := messageTemplates["default"]; ok {
+		return t, true
+	}
+	return MessageTemplate{}, false
+}
+
+// renderAttachments executes tmpl.Attachments against ctx and decodes the
+// resulting JSON into Slack attachments.
+func renderAttachments(tmpl MessageTemplate, ctx templateContext) ([]slack.Attachment, error) {
+	t, err := template.New("attachments").Parse(tmpl.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, ctx); err != nil {
+		return nil, err
+	}
+
+	var attachments []slack.Attachment
+	if err := json.Unmarshal(buf.Bytes(), &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// applyTemplate renders the operator-supplied override for event.Source, if
+// any, returning the attachments to send along with the Username/Icon
+// overrides. When no template is registered, or when detail can't be
+// decoded as JSON, it returns ok=false so the caller keeps the formatter's
+// default output.
+func applyTemplate(event events.CloudWatchEvent) (attachments []slack.Attachment, tmpl MessageTemplate, ok bool) {
+	tmpl, found := templateFor(event.Source)
+	if !found || tmpl.Attachments == "" {
+		return nil, MessageTemplate{}, false
+	}
+
+	var detail map[string]interface{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		Error.Println(err)
+		return nil, MessageTemplate{}, false
+	}
+
+	attachments, err := renderAttachments(tmpl, templateContext{Event: event, Detail: detail})
+	if err != nil {
+		Error.Println(err)
+		return nil, MessageTemplate{}, false
+	}
+
+	return attachments, tmpl, true
+}
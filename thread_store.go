@@ -0,0 +1,137 @@
+// Copyright 2018 Jonathan Monette
+//
+//    Licensed under the Apache License, Version 2.0 (the "License");
+//    you may not use this file except in compliance with the License.
+//    You may obtain a copy of the License at
+//
+//        http://www.apache.org/licenses/LICENSE-2.0
+//
+//    Unless required by applicable law or agreed to in writing, software
+//    distributed under the License is distributed on an "AS IS" BASIS,
+//    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//    See the License for the specific language governing permissions and
+//    limitations under the License.
+
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ThreadStore remembers the Slack thread_ts a prior event started, keyed by
+// threadKey, so repeated events for the same resource (an EMR cluster, a
+// pipeline execution, ...) reply in one thread instead of flooding the
+// channel with new top-level messages.
+type ThreadStore interface {
+	Get(key string) (ts string, ok bool)
+	Put(key string, ts string) error
+}
+
+// threadStore is selected once at init time: a DynamoDB-backed store when
+// THREAD_STORE_TABLE is set, so threads survive across cold starts, and an
+// in-memory store otherwise (best effort for the lifetime of the process).
+var threadStore ThreadStore
+
+func init() {
+	if table := os.Getenv("THREAD_STORE_TABLE"); table != "" {
+		sess, err := session.NewSession()
+		if err != nil {
+			Error.Println(err)
+		} else {
+			threadStore = dynamoThreadStore{table: table, client: dynamodb.New(sess)}
+			return
+		}
+	}
+
+	threadStore = newMemoryThreadStore()
+}
+
+// threadKey derives the stable ThreadStore key for event: a hash of its
+// Source and the first entry in Resources, which for most CloudWatch events
+// is the ARN of the resource (cluster, pipeline, task, ...) the event is
+// about. Sources that don't populate Resources fall back to the event's own
+// ID, which is unique per event, so resource-less events don't all collapse
+// into one ever-growing thread.
+func threadKey(event events.CloudWatchEvent) string {
+	resourceID := event.ID
+	if len(event.Resources) > 0 {
+		resourceID = event.Resources[0]
+	}
+
+	sum := sha1.Sum([]byte(event.Source + "|" + resourceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// memoryThreadStore is a process-lifetime ThreadStore, used when no durable
+// backing store is configured.
+type memoryThreadStore struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newMemoryThreadStore() *memoryThreadStore {
+	return &memoryThreadStore{data: map[string]string{}}
+}
+
+func (s *memoryThreadStore) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts, ok := s.data[key]
+	return ts, ok
+}
+
+func (s *memoryThreadStore) Put(key string, ts string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = ts
+	return nil
+}
+
+// dynamoThreadStore persists thread_ts values in a DynamoDB table with a
+// single string partition key named "key" and an attribute named "ts".
+type dynamoThreadStore struct {
+	table  string
+	client *dynamodb.DynamoDB
+}
+
+func (s dynamoThreadStore) Get(key string) (string, bool) {
+	out, err := s.client.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"key": {S: aws.String(key)},
+		},
+	})
+	if err != nil {
+		Error.Println(err)
+		return "", false
+	}
+	if out.Item == nil {
+		return "", false
+	}
+
+	ts, ok := out.Item["ts"]
+	if !ok || ts.S == nil {
+		return "", false
+	}
+
+	return *ts.S, true
+}
+
+func (s dynamoThreadStore) Put(key string, ts string) error {
+	_, err := s.client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"key": {S: aws.String(key)},
+			"ts":  {S: aws.String(ts)},
+		},
+	})
+	return err
+}